@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// buildACMEManager constructs an autocert.Manager from the
+// GATEWAY_ACME_HOSTS/GATEWAY_ACME_CACHE_DIR/GATEWAY_ACME_EMAIL environment
+// variables. It is split out from serveWithACME so it can be exercised by
+// tests without opening any listeners.
+func buildACMEManager() (*autocert.Manager, error) {
+	hosts := splitAndTrim(os.Getenv("GATEWAY_ACME_HOSTS"))
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("GATEWAY_ACME_ENABLED=true requires GATEWAY_ACME_HOSTS to be set")
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(hosts...),
+		Cache:      autocert.DirCache(getEnv("GATEWAY_ACME_CACHE_DIR", "./acme-cache")),
+	}
+	if email := strings.TrimSpace(os.Getenv("GATEWAY_ACME_EMAIL")); email != "" {
+		manager.Email = email
+	}
+	return manager, nil
+}
+
+// serveWithACME runs the gateway's public listener behind ACME-managed TLS:
+// manager.HTTPHandler answers HTTP-01 challenges on :80 while the real
+// traffic is served TLS-terminated on :443.
+func serveWithACME(handler http.Handler) error {
+	manager, err := buildACMEManager()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := http.ListenAndServe(":80", manager.HTTPHandler(nil)); err != nil {
+			log.Printf("gateway: acme http-01 challenge listener stopped: %v", err)
+		}
+	}()
+
+	server := &http.Server{
+		Addr:      ":443",
+		Handler:   handler,
+		TLSConfig: manager.TLSConfig(),
+	}
+	return server.ListenAndServeTLS("", "")
+}
+
+func splitAndTrim(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if host := strings.TrimSpace(part); host != "" {
+			out = append(out, host)
+		}
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+func TestBuildACMEManagerUsesConfiguredHostsAndCache(t *testing.T) {
+	t.Setenv("GATEWAY_ACME_HOSTS", "example.com, api.example.com")
+	cacheDir := t.TempDir()
+	t.Setenv("GATEWAY_ACME_CACHE_DIR", cacheDir)
+	t.Setenv("GATEWAY_ACME_EMAIL", "ops@example.com")
+
+	manager, err := buildACMEManager()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cache, ok := manager.Cache.(autocert.DirCache); !ok || string(cache) != cacheDir {
+		t.Fatalf("expected cache dir %q, got %#v", cacheDir, manager.Cache)
+	}
+
+	if manager.Email != "ops@example.com" {
+		t.Fatalf("expected configured contact email, got %q", manager.Email)
+	}
+	if err := manager.HostPolicy(context.Background(), "example.com"); err != nil {
+		t.Fatalf("expected example.com to be allowed: %v", err)
+	}
+	if err := manager.HostPolicy(context.Background(), "api.example.com"); err != nil {
+		t.Fatalf("expected api.example.com to be allowed: %v", err)
+	}
+	if err := manager.HostPolicy(context.Background(), "evil.example"); err == nil {
+		t.Fatal("expected an unlisted host to be rejected")
+	}
+}
+
+func TestBuildACMEManagerRequiresHosts(t *testing.T) {
+	t.Setenv("GATEWAY_ACME_HOSTS", "")
+
+	if _, err := buildACMEManager(); err == nil {
+		t.Fatal("expected an error when GATEWAY_ACME_HOSTS is unset")
+	}
+}
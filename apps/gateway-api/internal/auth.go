@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -10,56 +11,324 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/JudgeZ/OSS-AI-Agent-Tool/apps/gateway-api/internal/gateway"
 )
 
 const stateTTL = 10 * time.Minute
 
-type oauthProvider struct {
-	Name         string
-	AuthorizeURL string
-	RedirectURI  string
-	ClientID     string
-	Scopes       []string
+// Identity is the normalized representation of an authenticated user,
+// independent of which OAuth provider issued it.
+type Identity struct {
+	Provider    string `json:"provider"`
+	Subject     string `json:"subject"`
+	Email       string `json:"email"`
+	DisplayName string `json:"display_name"`
 }
 
-type stateData struct {
-	Provider     string
-	RedirectURI  string
-	CodeVerifier string
-	ExpiresAt    time.Time
-	State        string
+// Provider is an OAuth2/OIDC identity provider the gateway can send users
+// to and exchange an authorization code with. Implementations are looked
+// up by Name() from the provider registry.
+type Provider interface {
+	Name() string
+	AuthorizeURL(state, codeChallenge, redirectURI string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, redirectURI string) (string, error)
+	UserInfo(ctx context.Context, accessToken string) (Identity, error)
 }
 
-func getProviderConfig(provider string) (oauthProvider, error) {
-	redirectBase := strings.TrimRight(getEnv("OAUTH_REDIRECT_BASE", "http://127.0.0.1:8080"), "/")
-	configs := map[string]oauthProvider{
-		"openrouter": {
-			Name:         "openrouter",
-			AuthorizeURL: "https://openrouter.ai/oauth/authorize",
-			RedirectURI:  fmt.Sprintf("%s/auth/openrouter/callback", redirectBase),
-			ClientID:     os.Getenv("OPENROUTER_CLIENT_ID"),
-			Scopes:       []string{"offline", "openid", "profile"},
+// providerSpec describes a built-in OAuth provider. It is turned into a
+// genericProvider once its client ID has been resolved from the environment.
+type providerSpec struct {
+	name            string
+	authorizeURL    string
+	tokenURL        string
+	userInfoURL     string
+	scopes          []string
+	clientIDEnv     string
+	clientSecretEnv string
+	extractIdentity func(name string, raw map[string]any) Identity
+}
+
+// providerRegistry holds every built-in provider, keyed by name. Operators
+// enable a provider simply by setting its client ID (and, where required,
+// client secret) environment variables.
+var providerRegistry = map[string]*providerSpec{
+	"openrouter": {
+		name:         "openrouter",
+		authorizeURL: "https://openrouter.ai/oauth/authorize",
+		tokenURL:     "https://openrouter.ai/api/v1/auth/token",
+		scopes:       []string{"offline", "openid", "profile"},
+		clientIDEnv:  "OPENROUTER_CLIENT_ID",
+		extractIdentity: func(name string, _ map[string]any) Identity {
+			// OpenRouter's token endpoint does not expose a userinfo API;
+			// callers only get proof that the exchange succeeded.
+			return Identity{Provider: name}
+		},
+	},
+	"github": {
+		name:            "github",
+		authorizeURL:    "https://github.com/login/oauth/authorize",
+		tokenURL:        "https://github.com/login/oauth/access_token",
+		userInfoURL:     "https://api.github.com/user",
+		scopes:          []string{"read:user", "user:email"},
+		clientIDEnv:     "GITHUB_CLIENT_ID",
+		clientSecretEnv: "GITHUB_CLIENT_SECRET",
+		extractIdentity: func(name string, raw map[string]any) Identity {
+			display, _ := raw["name"].(string)
+			if display == "" {
+				display, _ = raw["login"].(string)
+			}
+			return Identity{
+				Provider:    name,
+				Subject:     stringifyID(raw["id"]),
+				Email:       stringField(raw, "email"),
+				DisplayName: display,
+			}
 		},
+	},
+	"google": {
+		name:            "google",
+		authorizeURL:    "https://accounts.google.com/o/oauth2/v2/auth",
+		tokenURL:        "https://oauth2.googleapis.com/token",
+		userInfoURL:     "https://openidconnect.googleapis.com/v1/userinfo",
+		scopes:          []string{"openid", "email", "profile"},
+		clientIDEnv:     "GOOGLE_CLIENT_ID",
+		clientSecretEnv: "GOOGLE_CLIENT_SECRET",
+		extractIdentity: func(name string, raw map[string]any) Identity {
+			return Identity{
+				Provider:    name,
+				Subject:     stringField(raw, "sub"),
+				Email:       stringField(raw, "email"),
+				DisplayName: stringField(raw, "name"),
+			}
+		},
+	},
+	"gitlab": {
+		name:            "gitlab",
+		authorizeURL:    "https://gitlab.com/oauth/authorize",
+		tokenURL:        "https://gitlab.com/oauth/token",
+		userInfoURL:     "https://gitlab.com/api/v4/user",
+		scopes:          []string{"read_user", "openid", "profile", "email"},
+		clientIDEnv:     "GITLAB_CLIENT_ID",
+		clientSecretEnv: "GITLAB_CLIENT_SECRET",
+		extractIdentity: func(name string, raw map[string]any) Identity {
+			display, _ := raw["name"].(string)
+			if display == "" {
+				display, _ = raw["username"].(string)
+			}
+			return Identity{
+				Provider:    name,
+				Subject:     stringifyID(raw["id"]),
+				Email:       stringField(raw, "email"),
+				DisplayName: display,
+			}
+		},
+	},
+	"microsoft": {
+		name:            "microsoft",
+		authorizeURL:    "https://login.microsoftonline.com/common/oauth2/v2.0/authorize",
+		tokenURL:        "https://login.microsoftonline.com/common/oauth2/v2.0/token",
+		userInfoURL:     "https://graph.microsoft.com/oidc/userinfo",
+		scopes:          []string{"openid", "email", "profile", "User.Read"},
+		clientIDEnv:     "MICROSOFT_CLIENT_ID",
+		clientSecretEnv: "MICROSOFT_CLIENT_SECRET",
+		extractIdentity: func(name string, raw map[string]any) Identity {
+			return Identity{
+				Provider:    name,
+				Subject:     stringField(raw, "sub"),
+				Email:       stringField(raw, "email"),
+				DisplayName: stringField(raw, "name"),
+			}
+		},
+	},
+}
+
+// genericProvider implements Provider for any OAuth2 authorization-code
+// flow described by a providerSpec.
+type genericProvider struct {
+	spec         *providerSpec
+	clientID     string
+	clientSecret string
+}
+
+func (p *genericProvider) Name() string { return p.spec.name }
+
+func (p *genericProvider) AuthorizeURL(state, codeChallenge, redirectURI string) (string, error) {
+	u, err := url.Parse(p.spec.authorizeURL)
+	if err != nil {
+		return "", err
 	}
-	cfg, ok := configs[provider]
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.clientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("state", state)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	if len(p.spec.scopes) > 0 {
+		q.Set("scope", strings.Join(p.spec.scopes, " "))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (p *genericProvider) Exchange(ctx context.Context, code, codeVerifier, redirectURI string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {p.clientID},
+		"code_verifier": {codeVerifier},
+	}
+	if p.clientSecret != "" {
+		form.Set("client_secret", p.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.spec.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s: token exchange request failed: %w", p.spec.name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to read token response: %w", p.spec.name, err)
+	}
+
+	var parsed map[string]any
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", fmt.Errorf("%s: failed to decode token response: %w", p.spec.name, err)
+	}
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("%s: token exchange failed: %s", p.spec.name, extractErrorMessage(body))
+	}
+
+	accessToken := stringField(parsed, "access_token")
+	if accessToken == "" {
+		return "", fmt.Errorf("%s: token response missing access_token", p.spec.name)
+	}
+	return accessToken, nil
+}
+
+func (p *genericProvider) UserInfo(ctx context.Context, accessToken string) (Identity, error) {
+	if p.spec.userInfoURL == "" {
+		return p.spec.extractIdentity(p.spec.name, nil), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.spec.userInfoURL, nil)
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%s: userinfo request failed: %w", p.spec.name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Identity{}, fmt.Errorf("%s: failed to read userinfo response: %w", p.spec.name, err)
+	}
+	if resp.StatusCode >= 400 {
+		return Identity{}, fmt.Errorf("%s: userinfo request failed: %s", p.spec.name, extractErrorMessage(body))
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return Identity{}, fmt.Errorf("%s: failed to decode userinfo response: %w", p.spec.name, err)
+	}
+	return p.spec.extractIdentity(p.spec.name, raw), nil
+}
+
+func stringField(raw map[string]any, key string) string {
+	if raw == nil {
+		return ""
+	}
+	v, _ := raw[key].(string)
+	return v
+}
+
+func stringifyID(v any) string {
+	switch id := v.(type) {
+	case string:
+		return id
+	case float64:
+		return fmt.Sprintf("%.0f", id)
+	default:
+		return ""
+	}
+}
+
+// getProvider resolves a Provider by name from the registry, failing if the
+// provider is unknown or its client ID has not been configured.
+func getProvider(name string) (Provider, error) {
+	spec, ok := providerRegistry[name]
 	if !ok {
-		return oauthProvider{}, fmt.Errorf("unknown provider: %s", provider)
+		return nil, fmt.Errorf("unknown provider: %s", name)
 	}
-	if cfg.ClientID == "" {
-		return oauthProvider{}, fmt.Errorf("provider %s is not configured", provider)
+	clientID := os.Getenv(spec.clientIDEnv)
+	if clientID == "" {
+		return nil, fmt.Errorf("provider %s is not configured", name)
+	}
+	var clientSecret string
+	if spec.clientSecretEnv != "" {
+		clientSecret = os.Getenv(spec.clientSecretEnv)
+	}
+	return &genericProvider{spec: spec, clientID: clientID, clientSecret: clientSecret}, nil
+}
+
+// configuredProviders returns the names of every registered provider whose
+// client ID is currently set, in a stable order.
+func configuredProviders() []string {
+	names := make([]string, 0, len(providerRegistry))
+	for name := range providerRegistry {
+		if _, err := getProvider(name); err == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func redirectURIFor(provider string) string {
+	redirectBase := strings.TrimRight(getEnv("OAUTH_REDIRECT_BASE", "http://127.0.0.1:8080"), "/")
+	return fmt.Sprintf("%s/auth/%s/callback", redirectBase, provider)
+}
+
+// RegisterOAuthRoutes wires up /auth/{name}/authorize and
+// /auth/{name}/callback for every provider that has been configured via
+// environment variables.
+func RegisterOAuthRoutes(mux *http.ServeMux) {
+	for _, name := range configuredProviders() {
+		mux.HandleFunc(fmt.Sprintf("/auth/%s/authorize", name), gateway.InstrumentOAuthAuthorize(name, authorizeHandler))
+		mux.HandleFunc(fmt.Sprintf("/auth/%s/callback", name), gateway.InstrumentOAuthCallback(name, callbackHandler))
+		log.Printf("auth: registered oauth routes for provider %q", name)
 	}
-	return cfg, nil
 }
 
 func authorizeHandler(w http.ResponseWriter, r *http.Request) {
 	provider := strings.TrimPrefix(r.URL.Path, "/auth/")
 	provider = strings.TrimSuffix(provider, "/authorize")
-	cfg, err := getProviderConfig(provider)
+	p, err := getProvider(provider)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -89,12 +358,19 @@ func authorizeHandler(w http.ResponseWriter, r *http.Request) {
 		State:        state,
 	}
 
-	if err := setStateCookie(w, r, data); err != nil {
+	store, err := getStateStore()
+	if err != nil {
+		http.Error(w, "state store is not available", http.StatusInternalServerError)
+		return
+	}
+	if err := store.Put(state, data); err != nil {
 		http.Error(w, "failed to persist state", http.StatusInternalServerError)
 		return
 	}
 
-	authURL, err := buildAuthorizeURL(cfg, state, codeChallenge)
+	setStateCookie(w, r, state, data.ExpiresAt)
+
+	authURL, err := p.AuthorizeURL(state, codeChallenge, redirectURIFor(provider))
 	if err != nil {
 		http.Error(w, "failed to build authorize url", http.StatusInternalServerError)
 		return
@@ -107,7 +383,7 @@ func callbackHandler(w http.ResponseWriter, r *http.Request) {
 	provider := strings.TrimPrefix(r.URL.Path, "/auth/")
 	provider = strings.TrimSuffix(provider, "/callback")
 
-	cfg, err := getProviderConfig(provider)
+	p, err := getProvider(provider)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -125,51 +401,81 @@ func callbackHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data, err := readStateCookie(r, state)
+	if err := verifyStateCookie(r, state); err != nil {
+		http.Error(w, "invalid or expired state", http.StatusBadRequest)
+		return
+	}
+	deleteStateCookie(w, r, state)
+
+	store, err := getStateStore()
+	if err != nil {
+		http.Error(w, "state store is not available", http.StatusInternalServerError)
+		return
+	}
+	data, err := store.Consume(state)
 	if err != nil || data.Provider != provider {
 		http.Error(w, "invalid or expired state", http.StatusBadRequest)
 		return
 	}
 
-	deleteStateCookie(w, r, state)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
 
+	accessToken, err := p.Exchange(ctx, code, data.CodeVerifier, redirectURIFor(provider))
+	if err != nil {
+		redirectWithStatus(w, r, data.RedirectURI, data.State, "error", err.Error())
+		return
+	}
+
+	identity, err := p.UserInfo(ctx, accessToken)
+	if err != nil {
+		redirectWithStatus(w, r, data.RedirectURI, data.State, "error", err.Error())
+		return
+	}
+
+	if err := notifyOrchestrator(ctx, identity, accessToken); err != nil {
+		redirectWithStatus(w, r, data.RedirectURI, data.State, "error", err.Error())
+		return
+	}
+
+	redirectWithStatus(w, r, data.RedirectURI, data.State, "success", "")
+}
+
+// notifyOrchestrator hands the verified identity to the orchestrator so it
+// can mint a session, independent of which provider produced it.
+func notifyOrchestrator(ctx context.Context, identity Identity, accessToken string) error {
 	payload := map[string]string{
-		"code":          code,
-		"code_verifier": data.CodeVerifier,
-		"redirect_uri":  cfg.RedirectURI,
+		"provider":     identity.Provider,
+		"subject":      identity.Subject,
+		"email":        identity.Email,
+		"display_name": identity.DisplayName,
+		"access_token": accessToken,
 	}
 
 	buf, err := json.Marshal(payload)
 	if err != nil {
-		http.Error(w, "failed to encode payload", http.StatusInternalServerError)
-		return
+		return fmt.Errorf("failed to encode payload: %w", err)
 	}
 	orchestratorURL := strings.TrimRight(getEnv("ORCHESTRATOR_URL", "http://127.0.0.1:4000"), "/")
-	endpoint := fmt.Sprintf("%s/auth/%s/callback", orchestratorURL, url.PathEscape(provider))
-	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
-	defer cancel()
+	endpoint := fmt.Sprintf("%s/auth/%s/callback", orchestratorURL, url.PathEscape(identity.Provider))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(buf))
 	if err != nil {
-		http.Error(w, "failed to create upstream request", http.StatusInternalServerError)
-		return
+		return fmt.Errorf("failed to create upstream request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		http.Error(w, "failed to contact orchestrator", http.StatusBadGateway)
-		return
+		return errors.New("failed to contact orchestrator")
 	}
 	defer resp.Body.Close()
 	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode >= 400 {
-		redirectWithStatus(w, r, data.RedirectURI, data.State, "error", extractErrorMessage(body))
-		return
+		return errors.New(extractErrorMessage(body))
 	}
-
-	redirectWithStatus(w, r, data.RedirectURI, data.State, "success", "")
+	return nil
 }
 
 func redirectError(w http.ResponseWriter, r *http.Request, _ string, errParam string) {
@@ -178,12 +484,22 @@ func redirectError(w http.ResponseWriter, r *http.Request, _ string, errParam st
 		http.Error(w, errParam, http.StatusBadRequest)
 		return
 	}
-	data, err := readStateCookie(r, state)
-	if err != nil {
+	if err := verifyStateCookie(r, state); err != nil {
 		http.Error(w, errParam, http.StatusBadRequest)
 		return
 	}
 	deleteStateCookie(w, r, state)
+
+	store, err := getStateStore()
+	if err != nil {
+		http.Error(w, errParam, http.StatusBadRequest)
+		return
+	}
+	data, err := store.Consume(state)
+	if err != nil {
+		http.Error(w, errParam, http.StatusBadRequest)
+		return
+	}
 	redirectWithStatus(w, r, data.RedirectURI, data.State, "error", errParam)
 }
 
@@ -241,25 +557,6 @@ func pkceChallenge(verifier string) string {
 	return base64.RawURLEncoding.EncodeToString(sum[:])
 }
 
-func buildAuthorizeURL(cfg oauthProvider, state, codeChallenge string) (string, error) {
-	u, err := url.Parse(cfg.AuthorizeURL)
-	if err != nil {
-		return "", err
-	}
-	q := u.Query()
-	q.Set("response_type", "code")
-	q.Set("client_id", cfg.ClientID)
-	q.Set("redirect_uri", cfg.RedirectURI)
-	q.Set("state", state)
-	q.Set("code_challenge", codeChallenge)
-	q.Set("code_challenge_method", "S256")
-	if len(cfg.Scopes) > 0 {
-		q.Set("scope", strings.Join(cfg.Scopes, " "))
-	}
-	u.RawQuery = q.Encode()
-	return u.String(), nil
-}
-
 func validateClientRedirect(redirectURI string) error {
 	u, err := url.Parse(redirectURI)
 	if err != nil {
@@ -284,52 +581,48 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-func setStateCookie(w http.ResponseWriter, r *http.Request, data stateData) error {
-	encoded, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
+type stateData struct {
+	Provider     string
+	RedirectURI  string
+	CodeVerifier string
+	ExpiresAt    time.Time
+	State        string
+}
 
-	cookie := &http.Cookie{
-		Name:     stateCookieName(data.State),
-		Value:    base64.RawURLEncoding.EncodeToString(encoded),
+// setStateCookie leaves only an HMAC-signed, opaque proof-of-possession on
+// the browser. The PKCE verifier and redirect URI live in the StateStore,
+// keyed by the same state value.
+func setStateCookie(w http.ResponseWriter, r *http.Request, state string, expiresAt time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookieName(state),
+		Value:    signState(state),
 		Path:     "/auth/",
-		Expires:  data.ExpiresAt,
-		MaxAge:   int(stateTTL.Seconds()),
+		Expires:  expiresAt,
+		MaxAge:   int(time.Until(expiresAt).Seconds()),
 		HttpOnly: true,
 		Secure:   isRequestSecure(r),
 		SameSite: http.SameSiteLaxMode,
-	}
-
-	http.SetCookie(w, cookie)
-	return nil
+	})
 }
 
-func readStateCookie(r *http.Request, state string) (stateData, error) {
+// verifyStateCookie checks that the browser completing the callback is the
+// same one the authorize step set a cookie for, without trusting anything
+// other than the HMAC signature.
+func verifyStateCookie(r *http.Request, state string) error {
 	cookie, err := r.Cookie(stateCookieName(state))
 	if err != nil {
-		return stateData{}, err
-	}
-
-	decoded, err := base64.RawURLEncoding.DecodeString(cookie.Value)
-	if err != nil {
-		return stateData{}, err
+		return errors.New("missing state cookie")
 	}
-
-	var data stateData
-	if err := json.Unmarshal(decoded, &data); err != nil {
-		return stateData{}, err
-	}
-
-	if data.State != state {
-		return stateData{}, errors.New("state mismatch")
-	}
-
-	if time.Now().After(data.ExpiresAt) {
-		return stateData{}, errors.New("state expired")
+	if !hmac.Equal([]byte(cookie.Value), []byte(signState(state))) {
+		return errors.New("state cookie signature mismatch")
 	}
+	return nil
+}
 
-	return data, nil
+func signState(state string) string {
+	mac := hmac.New(sha256.New, stateSecret())
+	mac.Write([]byte(state))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
 }
 
 func deleteStateCookie(w http.ResponseWriter, r *http.Request, state string) {
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestCallbackHandlerRejectsProviderMismatch covers the `data.Provider !=
+// provider` check in callbackHandler: a state handle minted for one provider
+// must not be honored by another provider's callback route, even if the
+// signed cookie and state value both check out.
+func TestCallbackHandlerRejectsProviderMismatch(t *testing.T) {
+	t.Setenv("GITHUB_CLIENT_ID", "test-client-id")
+	t.Setenv("GOOGLE_CLIENT_ID", "test-client-id")
+
+	SetStateStoreFactory(func() (StateStore, error) { return newMemoryStateStore(), nil })
+	defer ResetStateStore()
+
+	store, err := getStateStore()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	const state = "mismatched-state"
+	data := stateData{
+		Provider:     "google",
+		RedirectURI:  "https://example.com/callback",
+		CodeVerifier: "verifier",
+		ExpiresAt:    time.Now().Add(time.Minute),
+		State:        state,
+	}
+	if err := store.Put(state, data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/github/callback?code=abc&state="+state, nil)
+	req.AddCookie(&http.Cookie{Name: stateCookieName(state), Value: signState(state)})
+	rec := httptest.NewRecorder()
+
+	callbackHandler(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for a provider/state mismatch, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	if _, err := store.Consume(state); err == nil {
+		t.Fatal("expected the state to have already been consumed despite the provider mismatch")
+	}
+}
@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// parseSPKIPins decodes a comma-separated list of base64url-encoded
+// SHA-256 SubjectPublicKeyInfo hashes, as configured via
+// ORCHESTRATOR_TLS_PIN_SHA256. An empty input returns a nil (disabled) set.
+func parseSPKIPins(raw string) (map[string]struct{}, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	pins := make(map[string]struct{})
+	for _, entry := range strings.Split(raw, ",") {
+		pin := strings.TrimSpace(entry)
+		if pin == "" {
+			continue
+		}
+		if _, err := base64.RawURLEncoding.DecodeString(pin); err != nil {
+			return nil, fmt.Errorf("invalid ORCHESTRATOR_TLS_PIN_SHA256 entry %q: %w", pin, err)
+		}
+		pins[pin] = struct{}{}
+	}
+	return pins, nil
+}
+
+// spkiPinVerifier builds a tls.Config.VerifyPeerCertificate callback that
+// succeeds only if the presented chain contains a certificate whose
+// SubjectPublicKeyInfo hashes to one of the configured pins. It runs
+// alongside, not instead of, normal chain verification.
+func spkiPinVerifier(pins map[string]struct{}) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				continue
+			}
+			if _, ok := pins[spkiSHA256(cert)]; ok {
+				return nil
+			}
+		}
+		return fmt.Errorf("orchestrator certificate did not match any configured SPKI pin")
+	}
+}
+
+// spkiSHA256 returns the base64url-encoded SHA-256 hash of a certificate's
+// raw SubjectPublicKeyInfo.
+func spkiSHA256(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
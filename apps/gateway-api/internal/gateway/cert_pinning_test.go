@@ -0,0 +1,78 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSPKIPinVerifierMatchesConfiguredPin(t *testing.T) {
+	server := httptest.NewTLSServer(nil)
+	defer server.Close()
+
+	leaf := server.Certificate()
+	pins := map[string]struct{}{spkiSHA256(leaf): {}}
+
+	verify := spkiPinVerifier(pins)
+	if err := verify([][]byte{leaf.Raw}, nil); err != nil {
+		t.Fatalf("expected matching pin to verify, got error: %v", err)
+	}
+}
+
+func TestSPKIPinVerifierRejectsUnknownPin(t *testing.T) {
+	server := httptest.NewTLSServer(nil)
+	defer server.Close()
+
+	verify := spkiPinVerifier(map[string]struct{}{"not-a-real-pin": {}})
+	if err := verify([][]byte{server.Certificate().Raw}, nil); err == nil {
+		t.Fatal("expected verification to fail for a non-matching pin")
+	}
+}
+
+func TestBuildOrchestratorClientEnforcesConfiguredPin(t *testing.T) {
+	t.Setenv("ORCHESTRATOR_TLS_ENABLED", "0")
+	t.Cleanup(resetOrchestratorClient)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	pin := spkiSHA256(server.Certificate())
+
+	t.Run("matching pin succeeds", func(t *testing.T) {
+		t.Setenv("ORCHESTRATOR_TLS_PIN_SHA256", pin)
+
+		client, err := buildOrchestratorClient()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// The server uses a self-signed certificate; the pin must be
+		// validated against the real peer cert rather than a trust root,
+		// so skip chain verification but keep the pin callback engaged.
+		transport := client.Transport.(*http.Transport)
+		transport.TLSClientConfig.InsecureSkipVerify = true
+
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("expected request to succeed with matching pin, got: %v", err)
+		}
+		resp.Body.Close()
+	})
+
+	t.Run("mismatched pin fails", func(t *testing.T) {
+		t.Setenv("ORCHESTRATOR_TLS_PIN_SHA256", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA")
+		resetOrchestratorClient()
+
+		client, err := buildOrchestratorClient()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		transport := client.Transport.(*http.Transport)
+		transport.TLSClientConfig.InsecureSkipVerify = true
+
+		if _, err := client.Get(server.URL); err == nil {
+			t.Fatal("expected request to fail with a mismatched pin")
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package gateway
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const defaultCertReloadInterval = 60 * time.Second
+
+// certReloader owns the orchestrator client certificate and keeps it fresh.
+// It is installed as tls.Config.GetClientCertificate so every new
+// connection (including ones made mid-stream by the SSE proxy) picks up
+// the current certificate without tearing down existing connections.
+type certReloader struct {
+	certPath string
+	keyPath  string
+
+	mu      sync.RWMutex
+	cert    tls.Certificate
+	modTime time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// newCertReloader loads the certificate once so construction fails fast on
+// bad PEM material, then returns a reloader ready to be started.
+func newCertReloader(certPath, keyPath string) (*certReloader, error) {
+	r := &certReloader{
+		certPath: certPath,
+		keyPath:  keyPath,
+		stop:     make(chan struct{}),
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetClientCertificate implements the tls.Config hook used to serve
+// whichever certificate was most recently loaded from disk.
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cert := r.cert
+	return &cert, nil
+}
+
+// reload re-reads the certificate/key pair from disk. On failure it leaves
+// the previously loaded certificate in place so in-flight and future
+// connections keep working until the material on disk is fixed.
+func (r *certReloader) reload() error {
+	cert, err := loadClientCertificate(r.certPath, r.keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to load orchestrator client certificate: %w", err)
+	}
+
+	modTime := time.Time{}
+	if info, statErr := os.Stat(r.certPath); statErr == nil {
+		modTime = info.ModTime()
+	}
+
+	r.mu.Lock()
+	r.cert = cert
+	r.modTime = modTime
+	r.mu.Unlock()
+
+	if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil {
+		SetOrchestratorTLSCertExpiry(leaf.NotAfter)
+	}
+	return nil
+}
+
+// maybeReload reloads the certificate only if its mtime has moved forward,
+// so a steady-state timer tick is a cheap stat rather than a full parse.
+func (r *certReloader) maybeReload(reason string) {
+	info, err := os.Stat(r.certPath)
+	if err == nil {
+		r.mu.RLock()
+		unchanged := !info.ModTime().After(r.modTime)
+		r.mu.RUnlock()
+		if unchanged {
+			return
+		}
+	}
+
+	if err := r.reload(); err != nil {
+		log.Printf("orchestrator client cert reload (%s) failed, keeping previous certificate: %v", reason, err)
+		return
+	}
+	log.Printf("orchestrator client cert reloaded (%s) from %s", reason, r.certPath)
+}
+
+// start runs the periodic/SIGHUP reload loop until Stop is called.
+func (r *certReloader) start(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultCertReloadInterval
+	}
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(sighup)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				r.maybeReload("timer")
+			case <-sighup:
+				r.maybeReload("sighup")
+			}
+		}
+	}()
+}
+
+// Stop terminates the reload loop. It is safe to call more than once.
+func (r *certReloader) Stop() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+func certReloadInterval() time.Duration {
+	raw := os.Getenv("ORCHESTRATOR_CLIENT_CERT_RELOAD_INTERVAL")
+	if raw == "" {
+		return defaultCertReloadInterval
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return defaultCertReloadInterval
+	}
+	return d
+}
@@ -1,27 +1,39 @@
 package gateway
 
 import (
+	"bufio"
+	"bytes"
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	defaultHeartbeatInterval = 30 * time.Second
-	heartbeatPayload         = ": ping\n\n"
+	defaultHeartbeatInterval     = 30 * time.Second
+	heartbeatPayload             = ": ping\n\n"
+	reconnectingComment          = ": reconnecting\n\n"
+	defaultSSEBufferEvents       = 64
+	defaultReconnectBaseDelay    = 250 * time.Millisecond
+	defaultReconnectCeilingDelay = 30 * time.Second
 )
 
-// EventsHandler proxies Server-Sent Events streams from the orchestrator.
+// EventsHandler proxies Server-Sent Events streams from the orchestrator,
+// resuming the upstream connection with Last-Event-ID on transient failures
+// so a dropped orchestrator connection does not end the client's stream.
 type EventsHandler struct {
-	client            *http.Client
-	orchestratorURL   string
-	heartbeatInterval time.Duration
+	client                *http.Client
+	orchestratorURL       string
+	heartbeatInterval     time.Duration
+	bufferEvents          int
+	reconnectBaseDelay    time.Duration
+	reconnectCeilingDelay time.Duration
 }
 
 // NewEventsHandler constructs an SSE proxy handler that forwards requests to the orchestrator.
@@ -34,9 +46,12 @@ func NewEventsHandler(client *http.Client, orchestratorURL string, heartbeat tim
 		heartbeat = defaultHeartbeatInterval
 	}
 	return &EventsHandler{
-		client:            client,
-		orchestratorURL:   orchestratorURL,
-		heartbeatInterval: heartbeat,
+		client:                client,
+		orchestratorURL:       orchestratorURL,
+		heartbeatInterval:     heartbeat,
+		bufferEvents:          getEnvInt("GATEWAY_SSE_BUFFER_EVENTS", defaultSSEBufferEvents),
+		reconnectBaseDelay:    getEnvDuration("GATEWAY_SSE_RECONNECT_BASE_DELAY", defaultReconnectBaseDelay),
+		reconnectCeilingDelay: getEnvDuration("GATEWAY_SSE_RECONNECT_MAX_DELAY", defaultReconnectCeilingDelay),
 	}
 }
 
@@ -58,33 +73,21 @@ func (h *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "plan_id is required", http.StatusBadRequest)
 		return
 	}
+	planIDHash := hashPlanID(planID)
+	authHeader := r.Header.Get("Authorization")
+	lastEventID := r.Header.Get("Last-Event-ID")
 
-	upstreamURL := fmt.Sprintf("%s/plan/%s/events", h.orchestratorURL, url.PathEscape(planID))
 	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstreamURL, nil)
-	if err != nil {
-		http.Error(w, "failed to create upstream request", http.StatusInternalServerError)
-		return
-	}
-
-	req.Header.Set("Accept", "text/event-stream")
-	if auth := r.Header.Get("Authorization"); auth != "" {
-		req.Header.Set("Authorization", auth)
-	}
-	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
-		req.Header.Set("Last-Event-ID", lastEventID)
-	}
-
-	resp, err := h.client.Do(req)
+	resp, err := h.connectUpstream(ctx, planID, authHeader, lastEventID)
 	if err != nil {
 		http.Error(w, "failed to contact orchestrator", http.StatusBadGateway)
 		return
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
 		if len(body) == 0 {
 			http.Error(w, http.StatusText(resp.StatusCode), resp.StatusCode)
@@ -98,6 +101,7 @@ func (h *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
+		resp.Body.Close()
 		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
 		return
 	}
@@ -107,37 +111,280 @@ func (h *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Connection", "keep-alive")
 	flusher.Flush()
 
+	acquireSSEStreamGauge(planIDHash)
+	defer releaseSSEStreamGauge(planIDHash)
+
 	writer := &flushingWriter{w: w, flusher: flusher}
-	errCh := make(chan error, 1)
 
-	go func() {
-		_, err := io.Copy(writer, resp.Body)
-		errCh <- err
-	}()
+	eventState := &sseEventState{}
+	heartbeatStop := make(chan struct{})
+	go h.sendHeartbeats(ctx, writer, heartbeatStop, eventState)
+	defer close(heartbeatStop)
+
+	buffer := newSSERingBuffer(h.bufferEvents)
+	backoff := newBackoffPolicy(h.reconnectBaseDelay, h.reconnectCeilingDelay)
 
+	for {
+		eventState.open.Store(false)
+		streamSSE(resp.Body, writer, buffer, &lastEventID, eventState)
+		resp.Body.Close()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if _, err := writer.Write([]byte(reconnectingComment)); err != nil {
+			return
+		}
+
+		resp = h.reconnectWithBackoff(ctx, planID, authHeader, &lastEventID, backoff)
+		if resp == nil {
+			return
+		}
+	}
+}
+
+// connectUpstream issues the upstream SSE GET, forwarding the caller's
+// bearer token and the last event ID observed so far so the orchestrator can
+// resume the stream instead of replaying it from the start.
+func (h *EventsHandler) connectUpstream(ctx context.Context, planID, authHeader, lastEventID string) (*http.Response, error) {
+	upstreamURL := fmt.Sprintf("%s/plan/%s/events", h.orchestratorURL, url.PathEscape(planID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstreamURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	return h.client.Do(req)
+}
+
+// reconnectWithBackoff retries connectUpstream with exponential backoff until
+// it gets a usable response. It returns nil if the client disconnects or the
+// orchestrator answers with a 4xx status.
+func (h *EventsHandler) reconnectWithBackoff(ctx context.Context, planID, authHeader string, lastEventID *string, backoff *backoffPolicy) *http.Response {
+	for {
+		if !sleepOrDone(ctx, backoff.next()) {
+			return nil
+		}
+
+		resp, err := h.connectUpstream(ctx, planID, authHeader, *lastEventID)
+		if err != nil {
+			continue
+		}
+		if resp.StatusCode >= 500 {
+			resp.Body.Close()
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			// The orchestrator is rejecting the resumed stream outright
+			// (e.g. the plan no longer exists); further retries won't help.
+			resp.Body.Close()
+			return nil
+		}
+
+		backoff.reset()
+		return resp
+	}
+}
+
+// sseEventState tracks whether the proxy is currently mid-way through
+// forwarding an SSE event (i.e. has buffered lines since the last
+// event-terminating blank line). sendHeartbeats consults it so a heartbeat's
+// own blank line is never interleaved into an unterminated event, which
+// would make the client dispatch it as prematurely complete.
+type sseEventState struct {
+	open atomic.Bool
+}
+
+// sendHeartbeats writes periodic SSE comment lines so idle connections and
+// proxies in between don't time the stream out. It runs independently of
+// upstream reconnect attempts, skipping any tick that lands while an event is
+// still being forwarded so its blank line can't truncate that event.
+func (h *EventsHandler) sendHeartbeats(ctx context.Context, writer *flushingWriter, stop <-chan struct{}, state *sseEventState) {
 	ticker := time.NewTicker(h.heartbeatInterval)
 	defer ticker.Stop()
-
 	for {
 		select {
 		case <-ctx.Done():
-			resp.Body.Close()
-			<-errCh
 			return
-		case err := <-errCh:
-			if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, io.EOF) {
-				// Best-effort error propagation by terminating the stream.
-				http.Error(w, "stream interrupted", http.StatusBadGateway)
-			}
+		case <-stop:
 			return
 		case <-ticker.C:
-			if _, err := writer.Write([]byte(heartbeatPayload)); err != nil {
-				resp.Body.Close()
-				<-errCh
+			// Check and write under the writer's lock so event lines cannot be
+			// written between the check and the heartbeat.
+			writer.mu.Lock()
+			if state.open.Load() {
+				writer.mu.Unlock()
+				continue
+			}
+			_, err := writer.w.Write([]byte(heartbeatPayload))
+			if err == nil {
+				writer.flusher.Flush()
+			}
+			writer.mu.Unlock()
+			if err != nil {
 				return
 			}
+			sseHeartbeatsSentTotal.Inc()
+		}
+	}
+}
+
+// streamSSE reads upstream lines until the body ends or a read error occurs,
+// tracking the last "id:" field seen so the caller can resume from it. It
+// always returns once the body is exhausted; the caller decides whether that
+// warrants a reconnect.
+func streamSSE(body io.Reader, writer *flushingWriter, buffer *sseRingBuffer, lastEventID *string, state *sseEventState) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if id, ok := parseEventID(line); ok {
+			*lastEventID = id
 		}
+
+		atEventEnd := len(line) == 0
+		state.open.Store(!atEventEnd)
+
+		atCapacity := buffer.add(line)
+		if atCapacity || atEventEnd {
+			if err := flushRingBuffer(writer, buffer); err != nil {
+				return
+			}
+		}
+	}
+	flushRingBuffer(writer, buffer)
+}
+
+// parseEventID extracts the value of an SSE "id:" field, if line is one.
+func parseEventID(line []byte) (string, bool) {
+	const prefix = "id:"
+	if !bytes.HasPrefix(line, []byte(prefix)) {
+		return "", false
+	}
+	return string(bytes.TrimSpace(line[len(prefix):])), true
+}
+
+// flushRingBuffer writes and flushes any buffered lines as a single batch,
+// so an event's lines reach the client in fewer writes than one per line.
+func flushRingBuffer(writer *flushingWriter, buffer *sseRingBuffer) error {
+	lines := buffer.drain()
+	if len(lines) == 0 {
+		return nil
+	}
+	var batch bytes.Buffer
+	for _, line := range lines {
+		batch.Write(line)
+		batch.WriteByte('\n')
+	}
+	n, err := writer.Write(batch.Bytes())
+	if n > 0 {
+		sseBytesForwardedTotal.Add(float64(n))
+	}
+	return err
+}
+
+// sseRingBuffer accumulates parsed SSE lines up to a fixed capacity, bounding
+// memory use during a burst of upstream events before they are flushed to
+// the client.
+type sseRingBuffer struct {
+	lines    [][]byte
+	capacity int
+}
+
+func newSSERingBuffer(capacity int) *sseRingBuffer {
+	if capacity <= 0 {
+		capacity = defaultSSEBufferEvents
+	}
+	return &sseRingBuffer{capacity: capacity}
+}
+
+// add appends a copy of line to the buffer and reports whether the buffer has
+// reached capacity and should be flushed.
+func (b *sseRingBuffer) add(line []byte) bool {
+	cp := make([]byte, len(line))
+	copy(cp, line)
+	b.lines = append(b.lines, cp)
+	return len(b.lines) >= b.capacity
+}
+
+func (b *sseRingBuffer) drain() [][]byte {
+	lines := b.lines
+	b.lines = nil
+	return lines
+}
+
+// backoffPolicy implements exponential backoff with a ceiling, used between
+// upstream reconnect attempts.
+type backoffPolicy struct {
+	base    time.Duration
+	ceiling time.Duration
+	current time.Duration
+}
+
+func newBackoffPolicy(base, ceiling time.Duration) *backoffPolicy {
+	if base <= 0 {
+		base = defaultReconnectBaseDelay
+	}
+	if ceiling < base {
+		ceiling = base
+	}
+	return &backoffPolicy{base: base, ceiling: ceiling, current: base}
+}
+
+func (b *backoffPolicy) next() time.Duration {
+	delay := b.current
+	b.current *= 2
+	if b.current > b.ceiling {
+		b.current = b.ceiling
+	}
+	return delay
+}
+
+func (b *backoffPolicy) reset() {
+	b.current = b.base
+}
+
+// sleepOrDone waits for d, returning false early if ctx is canceled first.
+func sleepOrDone(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func getEnvInt(key string, fallback int) int {
+	raw := getEnv(key, "")
+	if raw == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return n
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	raw := getEnv(key, "")
+	if raw == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return fallback
 	}
+	return d
 }
 
 type flushingWriter struct {
@@ -0,0 +1,153 @@
+package gateway
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	oauthAuthorizeTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_oauth_authorize_total",
+		Help: "Total number of OAuth authorize redirects issued, by provider and result.",
+	}, []string{"provider", "result"})
+
+	oauthCallbackDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_oauth_callback_duration_seconds",
+		Help:    "Latency of OAuth callback handling, by provider and result.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"provider", "result"})
+
+	sseStreamsActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gateway_sse_streams_active",
+		Help: "Number of currently open SSE streams, by hashed plan ID.",
+	}, []string{"plan_id_hash"})
+
+	sseBytesForwardedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_sse_bytes_forwarded_total",
+		Help: "Total bytes forwarded from the orchestrator to SSE clients.",
+	})
+
+	sseHeartbeatsSentTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_sse_heartbeats_sent_total",
+		Help: "Total number of SSE heartbeat comments sent to clients.",
+	})
+
+	orchestratorTLSCertExpiry = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_orchestrator_tls_cert_expiry_seconds",
+		Help: "Unix timestamp, in seconds, when the loaded orchestrator client certificate expires.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		oauthAuthorizeTotal,
+		oauthCallbackDuration,
+		sseStreamsActive,
+		sseBytesForwardedTotal,
+		sseHeartbeatsSentTotal,
+		orchestratorTLSCertExpiry,
+	)
+}
+
+// RegisterMetricsRoutes registers the Prometheus scrape endpoint. Callers
+// should register it alongside RegisterHealthRoutes.
+func RegisterMetricsRoutes(mux *http.ServeMux) {
+	mux.Handle("/metrics", promhttp.Handler())
+}
+
+// statusRecordingWriter captures the status code a wrapped handler wrote,
+// defaulting to 200 for handlers that never explicitly set one.
+type statusRecordingWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecordingWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func outcomeFor(status int) string {
+	if status >= 400 {
+		return "error"
+	}
+	return "success"
+}
+
+// InstrumentOAuthAuthorize wraps an authorize handler to record
+// gateway_oauth_authorize_total without the handler needing to know about
+// metrics itself.
+func InstrumentOAuthAuthorize(provider string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecordingWriter{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		oauthAuthorizeTotal.WithLabelValues(provider, outcomeFor(rec.status)).Inc()
+	}
+}
+
+// InstrumentOAuthCallback wraps a callback handler to record
+// gateway_oauth_callback_duration_seconds without the handler needing to
+// know about metrics itself.
+func InstrumentOAuthCallback(provider string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecordingWriter{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		oauthCallbackDuration.WithLabelValues(provider, outcomeFor(rec.status)).Observe(time.Since(start).Seconds())
+	}
+}
+
+// hashPlanID hashes a plan ID before it is used as a metric label, so raw
+// plan identifiers never end up in scraped metrics.
+func hashPlanID(planID string) string {
+	sum := sha256.Sum256([]byte(planID))
+	return base64.RawURLEncoding.EncodeToString(sum[:8])
+}
+
+// sseStreamsActiveRefs tracks how many open streams currently hold the
+// gateway_sse_streams_active series for a given plan_id_hash, so the series
+// can be deleted once the last one ends. Without this, a distinct zero-valued
+// series accumulates in the registry for every plan ID the gateway has ever
+// streamed, since plan_id_hash cardinality is effectively unbounded.
+var (
+	sseStreamsActiveRefsMu sync.Mutex
+	sseStreamsActiveRefs   = make(map[string]int)
+)
+
+// acquireSSEStreamGauge increments gateway_sse_streams_active for
+// planIDHash and records that a stream now holds that series, so
+// releaseSSEStreamGauge knows when it's safe to delete it.
+func acquireSSEStreamGauge(planIDHash string) {
+	sseStreamsActiveRefsMu.Lock()
+	defer sseStreamsActiveRefsMu.Unlock()
+
+	sseStreamsActive.WithLabelValues(planIDHash).Inc()
+	sseStreamsActiveRefs[planIDHash]++
+}
+
+// releaseSSEStreamGauge decrements gateway_sse_streams_active for
+// planIDHash and, once no stream for that plan remains, removes the series
+// entirely instead of leaving a zero-valued entry behind.
+func releaseSSEStreamGauge(planIDHash string) {
+	sseStreamsActiveRefsMu.Lock()
+	defer sseStreamsActiveRefsMu.Unlock()
+
+	sseStreamsActive.WithLabelValues(planIDHash).Dec()
+	sseStreamsActiveRefs[planIDHash]--
+	if sseStreamsActiveRefs[planIDHash] <= 0 {
+		delete(sseStreamsActiveRefs, planIDHash)
+		sseStreamsActive.DeleteLabelValues(planIDHash)
+	}
+}
+
+// SetOrchestratorTLSCertExpiry records when the currently loaded
+// orchestrator client certificate expires.
+func SetOrchestratorTLSCertExpiry(notAfter time.Time) {
+	orchestratorTLSCertExpiry.Set(float64(notAfter.Unix()))
+}
@@ -0,0 +1,100 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInstrumentOAuthAuthorizeRecordsOutcome(t *testing.T) {
+	oauthAuthorizeTotal.Reset()
+
+	handler := InstrumentOAuthAuthorize("github", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://github.com/login/oauth/authorize", http.StatusFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/github/authorize", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if got := testutil.ToFloat64(oauthAuthorizeTotal.WithLabelValues("github", "success")); got != 1 {
+		t.Fatalf("expected 1 success authorize, got %v", got)
+	}
+}
+
+func TestInstrumentOAuthAuthorizeRecordsErrorOutcome(t *testing.T) {
+	oauthAuthorizeTotal.Reset()
+
+	handler := InstrumentOAuthAuthorize("github", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "redirect_uri is required", http.StatusBadRequest)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/github/authorize", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if got := testutil.ToFloat64(oauthAuthorizeTotal.WithLabelValues("github", "error")); got != 1 {
+		t.Fatalf("expected 1 error authorize, got %v", got)
+	}
+}
+
+func TestInstrumentOAuthCallbackRecordsDurationAndOutcome(t *testing.T) {
+	handler := InstrumentOAuthCallback("github", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid state", http.StatusBadRequest)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/github/callback", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	count := testutil.CollectAndCount(oauthCallbackDuration, "gateway_oauth_callback_duration_seconds")
+	if count == 0 {
+		t.Fatal("expected at least one callback duration observation to be recorded")
+	}
+}
+
+func TestReleaseSSEStreamGaugeDeletesSeriesWhenNoStreamsRemain(t *testing.T) {
+	sseStreamsActive.Reset()
+	const hash = "plan-hash"
+
+	acquireSSEStreamGauge(hash)
+	if got := testutil.ToFloat64(sseStreamsActive.WithLabelValues(hash)); got != 1 {
+		t.Fatalf("expected gauge of 1 after acquire, got %v", got)
+	}
+
+	releaseSSEStreamGauge(hash)
+	if testutil.CollectAndCount(sseStreamsActive) != 0 {
+		t.Fatal("expected the plan_id_hash series to be removed once its last stream ends")
+	}
+}
+
+func TestReleaseSSEStreamGaugeKeepsSeriesForConcurrentStreams(t *testing.T) {
+	sseStreamsActive.Reset()
+	const hash = "plan-hash"
+
+	acquireSSEStreamGauge(hash)
+	acquireSSEStreamGauge(hash)
+	releaseSSEStreamGauge(hash)
+
+	if got := testutil.ToFloat64(sseStreamsActive.WithLabelValues(hash)); got != 1 {
+		t.Fatalf("expected gauge of 1 while one stream for the plan is still open, got %v", got)
+	}
+
+	releaseSSEStreamGauge(hash)
+	if testutil.CollectAndCount(sseStreamsActive) != 0 {
+		t.Fatal("expected the series to be removed once the last concurrent stream ends")
+	}
+}
+
+func TestHashPlanIDIsStableAndDistinguishesPlans(t *testing.T) {
+	first := hashPlanID("plan-a")
+	second := hashPlanID("plan-a")
+	if first != second {
+		t.Fatal("expected hashing the same plan ID twice to produce the same hash")
+	}
+	if hashPlanID("plan-b") == first {
+		t.Fatal("expected different plan IDs to hash differently")
+	}
+}
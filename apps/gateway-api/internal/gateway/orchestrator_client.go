@@ -16,6 +16,7 @@ var (
 	orchestratorClient        *http.Client
 	orchestratorClientErr     error
 	orchestratorClientFactory = buildOrchestratorClient
+	orchestratorCertReloader  *certReloader
 	loadClientCertificate     = tls.LoadX509KeyPair
 )
 
@@ -30,21 +31,30 @@ func buildOrchestratorClient() (*http.Client, error) {
 	transport := http.DefaultTransport.(*http.Transport).Clone()
 	transport.ResponseHeaderTimeout = 30 * time.Second
 
-	if getBoolEnv("ORCHESTRATOR_TLS_ENABLED") {
-		clientCertPath := strings.TrimSpace(os.Getenv("ORCHESTRATOR_CLIENT_CERT"))
-		clientKeyPath := strings.TrimSpace(os.Getenv("ORCHESTRATOR_CLIENT_KEY"))
-		if clientCertPath == "" || clientKeyPath == "" {
-			return nil, fmt.Errorf("ORCHESTRATOR_TLS_ENABLED=true requires ORCHESTRATOR_CLIENT_CERT and ORCHESTRATOR_CLIENT_KEY to be set")
-		}
+	pins, err := parseSPKIPins(os.Getenv("ORCHESTRATOR_TLS_PIN_SHA256"))
+	if err != nil {
+		return nil, err
+	}
 
-		certificate, err := loadClientCertificate(clientCertPath, clientKeyPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load orchestrator client certificate: %w", err)
-		}
+	tlsEnabled := getBoolEnv("ORCHESTRATOR_TLS_ENABLED")
+	if tlsEnabled || len(pins) > 0 {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
 
-		tlsConfig := &tls.Config{
-			MinVersion:   tls.VersionTLS12,
-			Certificates: []tls.Certificate{certificate},
+		if tlsEnabled {
+			clientCertPath := strings.TrimSpace(os.Getenv("ORCHESTRATOR_CLIENT_CERT"))
+			clientKeyPath := strings.TrimSpace(os.Getenv("ORCHESTRATOR_CLIENT_KEY"))
+			if clientCertPath == "" || clientKeyPath == "" {
+				return nil, fmt.Errorf("ORCHESTRATOR_TLS_ENABLED=true requires ORCHESTRATOR_CLIENT_CERT and ORCHESTRATOR_CLIENT_KEY to be set")
+			}
+
+			reloader, err := newCertReloader(clientCertPath, clientKeyPath)
+			if err != nil {
+				return nil, err
+			}
+			reloader.start(certReloadInterval())
+			orchestratorCertReloader = reloader
+
+			tlsConfig.GetClientCertificate = reloader.GetClientCertificate
 		}
 
 		if caPath := strings.TrimSpace(os.Getenv("ORCHESTRATOR_CA_CERT")); caPath != "" {
@@ -63,6 +73,10 @@ func buildOrchestratorClient() (*http.Client, error) {
 			tlsConfig.ServerName = serverName
 		}
 
+		if len(pins) > 0 {
+			tlsConfig.VerifyPeerCertificate = spkiPinVerifier(pins)
+		}
+
 		transport.TLSClientConfig = tlsConfig
 	}
 
@@ -80,6 +94,10 @@ func ResetOrchestratorClient() {
 }
 
 func resetOrchestratorClient() {
+	if orchestratorCertReloader != nil {
+		orchestratorCertReloader.Stop()
+		orchestratorCertReloader = nil
+	}
 	orchestratorClientOnce = sync.Once{}
 	orchestratorClient = nil
 	orchestratorClientErr = nil
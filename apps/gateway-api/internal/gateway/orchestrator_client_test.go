@@ -3,8 +3,11 @@ package gateway
 import (
 	"crypto/tls"
 	"net/http"
+	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestBuildOrchestratorClientWithoutTLS(t *testing.T) {
@@ -40,6 +43,7 @@ func TestBuildOrchestratorClientConfiguresMutualTLS(t *testing.T) {
 	t.Setenv("ORCHESTRATOR_CLIENT_CERT", certPath)
 	t.Setenv("ORCHESTRATOR_CLIENT_KEY", keyPath)
 	t.Setenv("ORCHESTRATOR_TLS_SERVER_NAME", "orchestrator.internal")
+	t.Cleanup(resetOrchestratorClient)
 
 	originalLoader := loadClientCertificate
 	defer func() { loadClientCertificate = originalLoader }()
@@ -72,8 +76,15 @@ func TestBuildOrchestratorClientConfiguresMutualTLS(t *testing.T) {
 	if tlsConfig == nil {
 		t.Fatal("expected TLS config to be populated")
 	}
-	if len(tlsConfig.Certificates) != 1 {
-		t.Fatalf("expected exactly one certificate, got %d", len(tlsConfig.Certificates))
+	if tlsConfig.GetClientCertificate == nil {
+		t.Fatal("expected GetClientCertificate to be populated for hot-reload")
+	}
+	cert, err := tlsConfig.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("unexpected error fetching client certificate: %v", err)
+	}
+	if len(cert.Certificate) != 1 {
+		t.Fatalf("expected exactly one certificate, got %d", len(cert.Certificate))
 	}
 	if tlsConfig.ServerName != "orchestrator.internal" {
 		t.Fatalf("unexpected server name: %s", tlsConfig.ServerName)
@@ -82,3 +93,56 @@ func TestBuildOrchestratorClientConfiguresMutualTLS(t *testing.T) {
 		t.Fatalf("expected TLS v1.2 minimum, got %d", tlsConfig.MinVersion)
 	}
 }
+
+func TestCertReloaderPicksUpRotatedCertificate(t *testing.T) {
+	originalLoader := loadClientCertificate
+	defer func() { loadClientCertificate = originalLoader }()
+
+	certPath := filepath.Join(t.TempDir(), "client.crt")
+	keyPath := filepath.Join(t.TempDir(), "client.key")
+	if err := os.WriteFile(certPath, []byte("initial"), 0o600); err != nil {
+		t.Fatalf("failed to seed cert file: %v", err)
+	}
+
+	var served tls.Certificate
+	var mu sync.Mutex
+	loadClientCertificate = func(certFile, keyFile string) (tls.Certificate, error) {
+		data, err := os.ReadFile(certFile)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		served = tls.Certificate{Certificate: [][]byte{data}}
+		return served, nil
+	}
+
+	reloader, err := newCertReloader(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first, err := reloader.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(first.Certificate[0]) != "initial" {
+		t.Fatalf("expected initial certificate, got %q", first.Certificate[0])
+	}
+
+	// Simulate a rotated certificate landing on disk with a newer mtime.
+	time.Sleep(10 * time.Millisecond)
+	if err := os.WriteFile(certPath, []byte("rotated"), 0o600); err != nil {
+		t.Fatalf("failed to rotate cert file: %v", err)
+	}
+
+	reloader.maybeReload("test")
+
+	second, err := reloader.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(second.Certificate[0]) != "rotated" {
+		t.Fatalf("expected rotated certificate, got %q", second.Certificate[0])
+	}
+}
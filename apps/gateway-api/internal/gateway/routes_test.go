@@ -2,11 +2,13 @@ package gateway
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -59,6 +61,10 @@ func TestEventsHandlerForwardsSSEStream(t *testing.T) {
 			}
 			flusher.Flush()
 		}
+		// A real orchestrator keeps the stream open indefinitely; hold the
+		// connection until the client disconnects instead of returning,
+		// so a clean upstream close doesn't look like a drop to reconnect from.
+		<-r.Context().Done()
 	}))
 	defer orchestrator.Close()
 
@@ -70,7 +76,8 @@ func TestEventsHandlerForwardsSSEStream(t *testing.T) {
 	gatewaySrv := httptest.NewServer(mux)
 	defer gatewaySrv.Close()
 
-	req, err := http.NewRequest(http.MethodGet, gatewaySrv.URL+"/events?plan_id=abc-123", nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gatewaySrv.URL+"/events?plan_id=abc-123", nil)
 	if err != nil {
 		t.Fatalf("failed to create request: %v", err)
 	}
@@ -91,14 +98,23 @@ func TestEventsHandlerForwardsSSEStream(t *testing.T) {
 	}
 
 	reader := bufio.NewReader(resp.Body)
-	data, err := io.ReadAll(reader)
-	if err != nil {
-		t.Fatalf("failed to read response body: %v", err)
+	var seenFirst, seenSecond bool
+	for !seenSecond {
+		line, err := reader.ReadString('\n')
+		if strings.Contains(line, "data: first") {
+			seenFirst = true
+		}
+		if strings.Contains(line, "data: second") {
+			seenSecond = true
+		}
+		if err != nil {
+			break
+		}
 	}
+	cancel()
 
-	bodyStr := string(data)
-	if !strings.Contains(bodyStr, "data: first") || !strings.Contains(bodyStr, "data: second") {
-		t.Fatalf("gateway did not forward SSE events: %q", bodyStr)
+	if !seenFirst || !seenSecond {
+		t.Fatalf("gateway did not forward both SSE events (first=%v second=%v)", seenFirst, seenSecond)
 	}
 
 	select {
@@ -110,3 +126,153 @@ func TestEventsHandlerForwardsSSEStream(t *testing.T) {
 		t.Fatal("gateway did not call orchestrator")
 	}
 }
+
+func TestEventsHandlerDoesNotInterleaveHeartbeatIntoOpenEvent(t *testing.T) {
+	orchestrator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("upstream recorder missing flusher")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		// Write an event with more lines than the configured ring buffer
+		// capacity, pausing between lines so a heartbeat tick lands while
+		// the event is still open (no terminating blank line yet).
+		for i := 0; i < 6; i++ {
+			io.WriteString(w, "data: chunk\n")
+			flusher.Flush()
+			time.Sleep(15 * time.Millisecond)
+		}
+		io.WriteString(w, "\n")
+		flusher.Flush()
+		<-r.Context().Done()
+	}))
+	defer orchestrator.Close()
+
+	t.Setenv("GATEWAY_SSE_BUFFER_EVENTS", "2")
+
+	handler := NewEventsHandler(orchestrator.Client(), orchestrator.URL, 10*time.Millisecond)
+
+	mux := http.NewServeMux()
+	mux.Handle("/events", handler)
+
+	gatewaySrv := httptest.NewServer(mux)
+	defer gatewaySrv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gatewaySrv.URL+"/events?plan_id=abc-123", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := gatewaySrv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	var dataLines int
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		rawLine, err := reader.ReadString('\n')
+		line := strings.TrimRight(rawLine, "\n")
+
+		switch {
+		case line == "data: chunk":
+			dataLines++
+		case line == "":
+			// The event's real terminator must only arrive once all six
+			// chunks have, never spliced in early by a heartbeat. A blank
+			// line before the first chunk ends a heartbeat, not the event.
+			if dataLines > 0 && dataLines < 6 {
+				t.Fatalf("event was terminated early after only %d of 6 chunks", dataLines)
+			}
+		case strings.HasPrefix(line, ": ping"):
+			if dataLines > 0 && dataLines < 6 {
+				t.Fatalf("heartbeat was interleaved mid-event after %d of 6 chunks", dataLines)
+			}
+		}
+
+		if dataLines == 6 {
+			break
+		}
+		if err != nil {
+			t.Fatalf("stream ended before all chunks arrived (saw %d of 6): %v", dataLines, err)
+		}
+	}
+}
+
+func TestEventsHandlerReconnectsAfterUpstreamDrop(t *testing.T) {
+	var attempts int32
+	orchestrator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("upstream recorder missing flusher")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			io.WriteString(w, "id: 1\ndata: first\n\n")
+			flusher.Flush()
+			return // simulate a dropped connection
+		}
+
+		if got := r.Header.Get("Last-Event-ID"); got != "1" {
+			t.Errorf("expected reconnect to carry Last-Event-ID=1, got %q", got)
+		}
+		io.WriteString(w, "id: 2\ndata: second\n\n")
+		flusher.Flush()
+		<-r.Context().Done()
+	}))
+	defer orchestrator.Close()
+
+	t.Setenv("GATEWAY_SSE_RECONNECT_BASE_DELAY", "5ms")
+	t.Setenv("GATEWAY_SSE_RECONNECT_MAX_DELAY", "20ms")
+
+	handler := NewEventsHandler(orchestrator.Client(), orchestrator.URL, time.Minute)
+
+	mux := http.NewServeMux()
+	mux.Handle("/events", handler)
+
+	gatewaySrv := httptest.NewServer(mux)
+	defer gatewaySrv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gatewaySrv.URL+"/events?plan_id=abc-123", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := gatewaySrv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body strings.Builder
+	reader := bufio.NewReader(resp.Body)
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(body.String(), "data: second") && time.Now().Before(deadline) {
+		line, err := reader.ReadString('\n')
+		body.WriteString(line)
+		if err != nil {
+			break
+		}
+	}
+	cancel()
+
+	got := body.String()
+	if !strings.Contains(got, "data: first") || !strings.Contains(got, "data: second") {
+		t.Fatalf("expected both events to be delivered across the reconnect, got %q", got)
+	}
+	if !strings.Contains(got, ": reconnecting") {
+		t.Fatalf("expected a reconnecting comment to be emitted, got %q", got)
+	}
+	if strings.Count(got, "data: first") != 1 || strings.Count(got, "data: second") != 1 {
+		t.Fatalf("expected each event to be delivered exactly once, got %q", got)
+	}
+}
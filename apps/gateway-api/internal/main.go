@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JudgeZ/OSS-AI-Agent-Tool/apps/gateway-api/internal/gateway"
+)
+
+func main() {
+	mux := http.NewServeMux()
+	gateway.RegisterHealthRoutes(mux, time.Now())
+	gateway.RegisterMetricsRoutes(mux)
+	gateway.RegisterEventRoutes(mux)
+	RegisterOAuthRoutes(mux)
+
+	if getBoolEnv("GATEWAY_ACME_ENABLED") {
+		if err := serveWithACME(mux); err != nil {
+			log.Fatalf("gateway: acme listener failed: %v", err)
+		}
+		return
+	}
+
+	addr := getEnv("GATEWAY_ADDR", ":8080")
+	log.Printf("gateway: listening on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Fatalf("gateway: server failed: %v", err)
+	}
+}
+
+func getBoolEnv(key string) bool {
+	value := strings.TrimSpace(getEnv(key, ""))
+	if value == "" {
+		return false
+	}
+	switch strings.ToLower(value) {
+	case "1", "true", "yes", "on":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StateStore persists in-flight OAuth authorization attempts server-side so
+// the browser only ever holds an opaque, signed handle rather than the PKCE
+// verifier and redirect URI.
+type StateStore interface {
+	// Put records data under state, to be retrieved by exactly one Consume.
+	Put(state string, data stateData) error
+	// Consume atomically retrieves and deletes the entry for state. A
+	// second call for the same state must fail, which is what makes the
+	// authorization code flow replay-resistant.
+	Consume(state string) (stateData, error)
+	// GC evicts entries whose TTL has elapsed. It is a no-op for stores
+	// that expire entries natively (e.g. Redis).
+	GC()
+}
+
+var (
+	stateStoreOnce    sync.Once
+	stateStore        StateStore
+	stateStoreErr     error
+	stateStoreFactory = buildStateStore
+	stateStoreGCStop  chan struct{}
+)
+
+func getStateStore() (StateStore, error) {
+	stateStoreOnce.Do(func() {
+		stateStore, stateStoreErr = stateStoreFactory()
+	})
+	return stateStore, stateStoreErr
+}
+
+func buildStateStore() (StateStore, error) {
+	if redisURL := strings.TrimSpace(os.Getenv("OAUTH_STATE_REDIS_URL")); redisURL != "" {
+		opts, err := redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse OAUTH_STATE_REDIS_URL: %w", err)
+		}
+		return newRedisStateStore(redisClientAdapter{redis.NewClient(opts)}), nil
+	}
+
+	store := newMemoryStateStore()
+	stateStoreGCStop = make(chan struct{})
+	go store.runGC(stateStoreGCStop, stateTTL/2)
+	return store, nil
+}
+
+// SetStateStoreFactory overrides how the singleton StateStore is built,
+// primarily for tests.
+func SetStateStoreFactory(factory func() (StateStore, error)) {
+	stateStoreFactory = factory
+	resetStateStore()
+}
+
+// ResetStateStore restores the default StateStore factory.
+func ResetStateStore() {
+	stateStoreFactory = buildStateStore
+	resetStateStore()
+}
+
+func resetStateStore() {
+	if stateStoreGCStop != nil {
+		close(stateStoreGCStop)
+		stateStoreGCStop = nil
+	}
+	stateStoreOnce = sync.Once{}
+	stateStore = nil
+	stateStoreErr = nil
+}
+
+// memoryStateStore is the default StateStore: an in-process TTL map. It is
+// suitable for a single gateway replica; multi-replica deployments should
+// set OAUTH_STATE_REDIS_URL instead.
+type memoryStateStore struct {
+	mu      sync.Mutex
+	entries map[string]stateData
+}
+
+func newMemoryStateStore() *memoryStateStore {
+	return &memoryStateStore{entries: make(map[string]stateData)}
+}
+
+func (s *memoryStateStore) Put(state string, data stateData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[state] = data
+	return nil
+}
+
+func (s *memoryStateStore) Consume(state string) (stateData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.entries[state]
+	if !ok {
+		return stateData{}, errors.New("unknown or already consumed state")
+	}
+	delete(s.entries, state)
+	if time.Now().After(data.ExpiresAt) {
+		return stateData{}, errors.New("state expired")
+	}
+	return data, nil
+}
+
+func (s *memoryStateStore) GC() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for state, data := range s.entries {
+		if now.After(data.ExpiresAt) {
+			delete(s.entries, state)
+		}
+	}
+}
+
+func (s *memoryStateStore) runGC(stop <-chan struct{}, interval time.Duration) {
+	if interval <= 0 {
+		interval = stateTTL
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.GC()
+		}
+	}
+}
+
+// errStateNotFound is returned by a redisStateStoreClient when a key does
+// not exist, distinguishing "already consumed/unknown" from transport
+// errors without leaking the go-redis package into the StateStore API.
+var errStateNotFound = errors.New("state not found")
+
+// redisStateStoreClient is the minimal, driver-agnostic surface
+// redisStateStore needs, so tests can substitute a fake without a live
+// Redis server.
+type redisStateStoreClient interface {
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	GetDel(ctx context.Context, key string) (string, error)
+}
+
+// redisClientAdapter adapts *redis.Client to redisStateStoreClient,
+// translating the driver's sentinel "no such key" error into
+// errStateNotFound.
+type redisClientAdapter struct {
+	client *redis.Client
+}
+
+func (a redisClientAdapter) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return a.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (a redisClientAdapter) GetDel(ctx context.Context, key string) (string, error) {
+	value, err := a.client.GetDel(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", errStateNotFound
+	}
+	return value, err
+}
+
+// redisStateStore backs the StateStore with Redis so state survives across
+// gateway replicas and restarts. Redis' own TTL handles expiry, and GetDel
+// makes Consume atomic without a Lua script.
+type redisStateStore struct {
+	client redisStateStoreClient
+}
+
+func newRedisStateStore(client redisStateStoreClient) *redisStateStore {
+	return &redisStateStore{client: client}
+}
+
+func (s *redisStateStore) Put(state string, data stateData) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(data.ExpiresAt)
+	if ttl <= 0 {
+		return errors.New("state already expired")
+	}
+	return s.client.Set(context.Background(), redisStateKey(state), string(encoded), ttl)
+}
+
+func (s *redisStateStore) Consume(state string) (stateData, error) {
+	raw, err := s.client.GetDel(context.Background(), redisStateKey(state))
+	if errors.Is(err, errStateNotFound) {
+		return stateData{}, errors.New("unknown or already consumed state")
+	}
+	if err != nil {
+		return stateData{}, err
+	}
+	var data stateData
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return stateData{}, err
+	}
+	return data, nil
+}
+
+func (s *redisStateStore) GC() {
+	// Redis expires keys on its own; nothing to sweep here.
+}
+
+func redisStateKey(state string) string {
+	return "oauth_state:" + state
+}
+
+// stateSecret returns the key used to sign the browser-facing state handle.
+// If OAUTH_STATE_SECRET is not configured, an ephemeral per-process secret
+// is generated so cookies still cannot be forged, at the cost of not
+// validating across restarts or multiple gateway replicas.
+var stateSecret = sync.OnceValue(func() []byte {
+	if secret := strings.TrimSpace(os.Getenv("OAUTH_STATE_SECRET")); secret != "" {
+		return []byte(secret)
+	}
+	log.Printf("auth: OAUTH_STATE_SECRET is not set; using an ephemeral per-process secret")
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("auth: failed to generate ephemeral state secret: %v", err))
+	}
+	return buf
+})
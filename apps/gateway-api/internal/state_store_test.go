@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStateStoreConsumeIsOneTime(t *testing.T) {
+	store := newMemoryStateStore()
+	data := stateData{
+		Provider:     "github",
+		RedirectURI:  "https://example.com/callback",
+		CodeVerifier: "verifier",
+		ExpiresAt:    time.Now().Add(time.Minute),
+		State:        "abc123",
+	}
+
+	if err := store.Put(data.State, data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := store.Consume(data.State)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.CodeVerifier != data.CodeVerifier {
+		t.Fatalf("expected verifier %q, got %q", data.CodeVerifier, got.CodeVerifier)
+	}
+
+	if _, err := store.Consume(data.State); err == nil {
+		t.Fatal("expected second consume of the same state to fail")
+	}
+}
+
+func TestMemoryStateStoreRejectsExpiredEntries(t *testing.T) {
+	store := newMemoryStateStore()
+	data := stateData{State: "expired", ExpiresAt: time.Now().Add(-time.Minute)}
+
+	if err := store.Put(data.State, data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := store.Consume(data.State); err == nil {
+		t.Fatal("expected consume of an expired state to fail")
+	}
+}
+
+func TestMemoryStateStoreGCEvictsExpiredEntries(t *testing.T) {
+	store := newMemoryStateStore()
+	store.entries["stale"] = stateData{State: "stale", ExpiresAt: time.Now().Add(-time.Minute)}
+	store.entries["fresh"] = stateData{State: "fresh", ExpiresAt: time.Now().Add(time.Minute)}
+
+	store.GC()
+
+	if _, ok := store.entries["stale"]; ok {
+		t.Fatal("expected GC to evict the expired entry")
+	}
+	if _, ok := store.entries["fresh"]; !ok {
+		t.Fatal("expected GC to keep the unexpired entry")
+	}
+}
+
+func TestSignStateIsDeterministicAndDistinguishesStates(t *testing.T) {
+	first := signState("state-a")
+	second := signState("state-a")
+	if first != second {
+		t.Fatal("expected signing the same state twice to produce the same signature")
+	}
+	if signState("state-b") == first {
+		t.Fatal("expected different states to produce different signatures")
+	}
+}
+
+// fakeRedisClient is an in-memory stand-in for redisStateStoreClient so the
+// redisStateStore's atomic-consume behavior can be tested without a live
+// Redis server.
+type fakeRedisClient struct {
+	values map[string]string
+}
+
+func newFakeRedisClient() *fakeRedisClient {
+	return &fakeRedisClient{values: make(map[string]string)}
+}
+
+func (c *fakeRedisClient) Set(_ context.Context, key, value string, _ time.Duration) error {
+	c.values[key] = value
+	return nil
+}
+
+func (c *fakeRedisClient) GetDel(_ context.Context, key string) (string, error) {
+	value, ok := c.values[key]
+	if !ok {
+		return "", errStateNotFound
+	}
+	delete(c.values, key)
+	return value, nil
+}
+
+func TestRedisStateStoreConsumeIsAtomicAndOneTime(t *testing.T) {
+	store := newRedisStateStore(newFakeRedisClient())
+	data := stateData{State: "abc", CodeVerifier: "verifier", ExpiresAt: time.Now().Add(time.Minute)}
+
+	if err := store.Put(data.State, data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := store.Consume(data.State)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.CodeVerifier != data.CodeVerifier {
+		t.Fatalf("expected verifier %q, got %q", data.CodeVerifier, got.CodeVerifier)
+	}
+
+	if _, err := store.Consume(data.State); err == nil {
+		t.Fatal("expected second consume of the same state to fail")
+	}
+}
+
+func TestRedisStateStoreConsumeSurfacesNotFound(t *testing.T) {
+	store := newRedisStateStore(newFakeRedisClient())
+	if _, err := store.Consume("missing"); err == nil {
+		t.Fatal("expected consume of a missing key to fail")
+	}
+}
+
+func TestRedisStateStorePutRejectsAlreadyExpired(t *testing.T) {
+	store := newRedisStateStore(newFakeRedisClient())
+	data := stateData{State: "abc", ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := store.Put(data.State, data); err == nil {
+		t.Fatal("expected put of an already-expired state to fail")
+	}
+}